@@ -4,42 +4,358 @@ package ft_logging
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"log"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
 )
 
+// defaultStacktraceFrames is the default cap on captured stack frames.
+const defaultStacktraceFrames = 32
+
+// stackFrame is a single captured call stack entry.
+type stackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// internalFramePrefix is this package's own import path, as reported by
+// runtime.Frame.Function, derived via reflection so it stays correct
+// regardless of the module path the package is vendored under.
+var internalFramePrefix = reflect.TypeOf(Service{}).PkgPath() + "."
+
+// internalFrameNames lists the ft_logging call frames (relative to
+// internalFramePrefix) that sit between a logging method and its caller.
+// captureStacktrace skips any frame matching one of these so the captured
+// trace starts at the code that actually triggered the log call.
+var internalFrameNames = map[string]bool{
+	"captureStacktrace":  true,
+	"(*Service).log":     true,
+	"(*Service).Debug":   true,
+	"(*Service).Info":    true,
+	"(*Service).Success": true,
+	"(*Service).Warn":    true,
+	"(*Service).Error":   true,
+	"(*Service).Fatal":   true,
+}
+
+// isInternalFrame reports whether function is one of ft_logging's own
+// logging frames rather than caller code. It matches on the fully
+// qualified, package-prefixed function name so a caller's own type (e.g.
+// a consumer's `type Service struct{...}` with an `Error` method) is
+// never mistaken for this package's frames.
+func isInternalFrame(function string) bool {
+	name, ok := strings.CutPrefix(function, internalFramePrefix)
+	if !ok {
+		return false
+	}
+	return internalFrameNames[name]
+}
+
+// captureStacktrace walks the call stack, skipping frames inside
+// ft_logging itself, and returns up to maxFrames entries starting at the
+// caller of the logging method.
+func captureStacktrace(maxFrames int) []stackFrame {
+	var (
+		pcs    []uintptr
+		n      int
+		frames *runtime.Frames
+		frame  runtime.Frame
+		more   bool
+		result []stackFrame
+	)
+
+	if maxFrames <= 0 {
+		maxFrames = defaultStacktraceFrames
+	}
+
+	// extra headroom: frames inside ft_logging itself are skipped below
+	// and don't count against maxFrames.
+	pcs = make([]uintptr, maxFrames+16)
+	n = runtime.Callers(2, pcs)
+	frames = runtime.CallersFrames(pcs[:n])
+
+	result = make([]stackFrame, 0, maxFrames)
+	for {
+		frame, more = frames.Next()
+		if !isInternalFrame(frame.Function) {
+			result = append(result, stackFrame{Function: frame.Function, File: frame.File, Line: frame.Line})
+			if len(result) >= maxFrames {
+				break
+			}
+		}
+		if !more {
+			break
+		}
+	}
+	return result
+}
+
+// ansiEscapePattern matches ANSI/SGR color escape sequences.
+var ansiEscapePattern = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// ansiStrippingWriter strips ANSI color escapes before writing to w. It
+// always reports having written the full input, since the stripped length
+// legitimately differs from len(p).
+type ansiStrippingWriter struct {
+	w io.Writer
+}
+
+func (a *ansiStrippingWriter) Write(p []byte) (int, error) {
+	_, err := a.w.Write(ansiEscapePattern.ReplaceAll(p, nil))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// wrapSink strips ANSI escapes for *os.File destinations that aren't a
+// terminal (e.g. redirected to a file or piped), leaving other writers
+// (in-memory buffers, already-wrapped sinks, ...) untouched.
+func wrapSink(w io.Writer) io.Writer {
+	if f, ok := w.(*os.File); ok {
+		if !term.IsTerminal(int(f.Fd())) {
+			return &ansiStrippingWriter{w: f}
+		}
+	}
+	return w
+}
+
 // ANSI color codes for terminal output
 const (
-	colorReset  = "\033[0m"
-	colorRed    = "\033[31m"
-	colorGreen  = "\033[32m"
-	colorWhite  = "\033[37m"
+	colorReset   = "\033[0m"
+	colorRed     = "\033[31m"
+	colorGreen   = "\033[32m"
+	colorWhite   = "\033[37m"
+	colorYellow  = "\033[33m"
+	colorMagenta = "\033[35m"
+	colorBoldRed = "\033[1;31m"
+)
+
+// Level is an ordered logging severity. Lower values are more verbose.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelSuccess
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+// levelMeta holds the display name and color associated with each Level.
+var levelMeta = map[Level]struct {
+	Name  string
+	Color string
+}{
+	LevelDebug:   {"DEBUG", colorMagenta},
+	LevelInfo:    {"INFO", colorWhite},
+	LevelSuccess: {"SUCCESS", colorGreen},
+	LevelWarn:    {"WARN", colorYellow},
+	LevelError:   {"ERROR", colorRed},
+	LevelFatal:   {"FATAL", colorBoldRed},
+}
+
+// String returns the display name for the level (e.g. "DEBUG").
+func (l Level) String() string {
+	if meta, ok := levelMeta[l]; ok {
+		return meta.Name
+	}
+	return "UNKNOWN"
+}
+
+// levelFromName reverse-looks-up a Level from its display name.
+func levelFromName(name string) (Level, bool) {
+	for lvl, meta := range levelMeta {
+		if meta.Name == name {
+			return lvl, true
+		}
+	}
+	return 0, false
+}
+
+// Format selects how log lines are rendered.
+type Format int
+
+const (
+	// FormatText renders one ANSI-colored line per log call (the default).
+	FormatText Format = iota
+	// FormatJSON renders one JSON object per log call, with colors disabled.
+	FormatJSON
 )
 
+// attr is a single key/value attribute attached to a log line, either
+// extracted from context or passed explicitly by the caller.
+type attr struct {
+	Key   string
+	Value any
+}
+
 // Logger defines the logging interface with three methods.
+// Info, Success, and Error accept an optional list of key/value pairs
+// (mirroring slog's attribute style) that are rendered alongside the
+// message in both text and JSON output modes.
 type Logger interface {
 	// Info logs an informational message in white.
-	Info(ctx context.Context, message string)
+	Info(ctx context.Context, message string, kv ...any)
 
 	// Success logs a success message in green.
-	Success(ctx context.Context, message string)
+	Success(ctx context.Context, message string, kv ...any)
+
+	// Error logs an error message in red. If a single error value is
+	// passed as the only key/value argument, it is recorded under the
+	// "error" key.
+	Error(ctx context.Context, message string, kv ...any)
+
+	// Debug logs a debug message in magenta. Gated by the configured level.
+	Debug(ctx context.Context, message string, kv ...any)
 
-	// Error logs an error message in red.
-	Error(ctx context.Context, message string)
+	// Warn logs a warning message in yellow. Gated by the configured level.
+	Warn(ctx context.Context, message string, kv ...any)
+
+	// Fatal logs a message in bold red, then calls os.Exit(1).
+	Fatal(ctx context.Context, message string, kv ...any)
+
+	// SetLevel sets the minimum level that will be logged.
+	SetLevel(level Level)
+
+	// V reports whether level would currently be logged, so callers can
+	// cheaply gate expensive log construction:
+	//   if logger.V(ft_logging.LevelDebug) { logger.Debug(ctx, buildExpensiveMsg()) }
+	V(level Level) bool
 }
 
+// ContextAttrFunc extracts key/value pairs (in the same alternating
+// "key", value, "key2", value2 style as the variadic logging methods) from
+// a context.Context. Register one via WithContextAttrFuncs to pull values
+// out of unexported/typed context keys - the idiomatic Go pattern - without
+// the logger needing to know the key type.
+type ContextAttrFunc func(ctx context.Context) []any
+
 // Service implements the Logger interface.
 type Service struct {
-	contextKeys []string
+	contextKeys      []string
+	contextAttrFuncs []ContextAttrFunc
+	format           Format
+	level            Level
+	addStacktrace    bool
+	stacktraceLevel  Level
+	stacktraceDepth  int
+
+	mu     sync.Mutex
+	output io.Writer
+}
+
+// Option configures a Service at construction time.
+type Option func(*Service)
+
+// WithFormat sets the output format (FormatText or FormatJSON).
+func WithFormat(format Format) Option {
+	return func(s *Service) {
+		s.format = format
+	}
+}
+
+// WithLevel sets the minimum level that will be logged. The default is
+// LevelDebug, meaning nothing is filtered unless SetLevel/WithLevel is used.
+func WithLevel(level Level) Option {
+	return func(s *Service) {
+		s.level = level
+	}
+}
+
+// WithAddStacktrace enables or disables call stack capture on log lines at
+// or above the configured stacktrace level (LevelError by default).
+func WithAddStacktrace(enabled bool) Option {
+	return func(s *Service) {
+		s.addStacktrace = enabled
+	}
+}
+
+// WithStacktraceLevel sets the minimum level at which a stack trace is
+// captured when WithAddStacktrace(true) is set. The default is LevelError.
+func WithStacktraceLevel(level Level) Option {
+	return func(s *Service) {
+		s.stacktraceLevel = level
+	}
+}
+
+// WithStacktraceDepth caps the number of captured stack frames. The
+// default is 32.
+func WithStacktraceDepth(depth int) Option {
+	return func(s *Service) {
+		s.stacktraceDepth = depth
+	}
+}
+
+// WithContextAttrFuncs registers additional ContextAttrFunc extractors,
+// appended to any already registered. Their results are merged with the
+// legacy contextKeys list, deduplicated by key name (a later value for the
+// same key wins).
+func WithContextAttrFuncs(fns ...ContextAttrFunc) Option {
+	return func(s *Service) {
+		s.contextAttrFuncs = append(s.contextAttrFuncs, fns...)
+	}
+}
+
+// WithOutput sets the destination for log lines, replacing the default of
+// os.Stderr. Writes are serialized by the Service's own mutex, so this is
+// safe to share across goroutines and doesn't touch process-global state.
+func WithOutput(w io.Writer) Option {
+	return func(s *Service) {
+		s.output = wrapSink(w)
+	}
+}
+
+// WithOutputs fans a single log line out to multiple destinations (e.g.
+// stderr plus a rotating file), writing to each under the same mutex so a
+// line is never interleaved across destinations.
+func WithOutputs(writers ...io.Writer) Option {
+	return func(s *Service) {
+		wrapped := make([]io.Writer, 0, len(writers))
+		for _, w := range writers {
+			wrapped = append(wrapped, wrapSink(w))
+		}
+		s.output = io.MultiWriter(wrapped...)
+	}
 }
 
 // NewLogger creates a new Logger instance with optional context keys to extract.
 // contextKeys is a slice of context keys to extract and log (pass nil or empty slice if not needed).
+// opts applies functional options such as WithFormat; the default format is FormatText.
 // Returns a Logger interface implementation.
 //
 // Example:
-//   logger := ft_logging.NewLogger([]string{"request_id", "user_id", "trace_id"})
-func NewLogger(contextKeys []string) Logger {
+//
+//	logger := ft_logging.NewLogger([]string{"request_id", "user_id", "trace_id"})
+func NewLogger(contextKeys []string, opts ...Option) Logger {
+	return newService(contextKeys, FormatText, opts)
+}
+
+// NewStructuredLogger creates a Logger that emits one JSON object per log
+// line (fields: time, level, msg, error, plus any extracted context keys
+// and caller-supplied attributes promoted to top level). Pass WithFormat
+// to fall back to FormatText while keeping the rest of the structured API.
+//
+// Example:
+//
+//	logger := ft_logging.NewStructuredLogger([]string{"request_id"})
+func NewStructuredLogger(contextKeys []string, opts ...Option) Logger {
+	return newService(contextKeys, FormatJSON, opts)
+}
+
+// newService builds a Service with the given default format, then applies opts.
+func newService(contextKeys []string, defaultFormat Format, opts []Option) *Service {
 	var (
 		service *Service
 		keys    string
@@ -48,12 +364,21 @@ func NewLogger(contextKeys []string) Logger {
 	)
 
 	service = &Service{
-		contextKeys: contextKeys,
+		contextKeys:     contextKeys,
+		format:          defaultFormat,
+		stacktraceLevel: LevelError,
+		stacktraceDepth: defaultStacktraceFrames,
+		output:          wrapSink(os.Stderr),
+	}
+
+	for _, opt := range opts {
+		opt(service)
 	}
 
 	// print initialization details
+	var initMessage string
 	if len(contextKeys) == 0 {
-		log.Printf("[ft_logging] Initialized with no context extraction")
+		initMessage = "[ft_logging] Initialized with no context extraction"
 	} else {
 		keys = ""
 		for i, key = range contextKeys {
@@ -62,93 +387,333 @@ func NewLogger(contextKeys []string) Logger {
 			}
 			keys += key
 		}
-		log.Printf("[ft_logging] Initialized with context keys: [%s]", keys)
+		initMessage = fmt.Sprintf("[ft_logging] Initialized with context keys: [%s]", keys)
+	}
+	if service.format == FormatJSON {
+		service.writeJSON(LevelInfo.String(), initMessage, nil, nil, nil)
+	} else {
+		service.writeLine(initMessage)
 	}
 
 	return service
 }
 
+// writeLine writes a single log line to the configured output, serialized
+// by mu so concurrent callers never interleave, and appends a trailing
+// newline if line doesn't already end with one.
+func (s *Service) writeLine(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	io.WriteString(s.output, line)
+	if len(line) == 0 || line[len(line)-1] != '\n' {
+		io.WriteString(s.output, "\n")
+	}
+}
+
+// Debug logs a debug message in magenta.
+// ctx is the context for extracting context values.
+// message is the log message to display.
+// kv is an optional list of key/value pairs, e.g. Debug(ctx, "msg", "key", "value").
+func (s *Service) Debug(ctx context.Context, message string, kv ...any) {
+	s.log(ctx, LevelDebug, message, kv)
+}
+
 // Info logs an informational message in white.
 // ctx is the context for extracting context values.
 // message is the log message to display.
-func (s *Service) Info(ctx context.Context, message string) {
-	s.LogWithColor(ctx, colorWhite, "INFO", message)
+// kv is an optional list of key/value pairs, e.g. Info(ctx, "msg", "key", "value").
+func (s *Service) Info(ctx context.Context, message string, kv ...any) {
+	s.log(ctx, LevelInfo, message, kv)
 }
 
 // Success logs a success message in green.
 // ctx is the context for extracting context values.
 // message is the log message to display.
-func (s *Service) Success(ctx context.Context, message string) {
-	s.LogWithColor(ctx, colorGreen, "SUCCESS", message)
+// kv is an optional list of key/value pairs, e.g. Success(ctx, "msg", "key", "value").
+func (s *Service) Success(ctx context.Context, message string, kv ...any) {
+	s.log(ctx, LevelSuccess, message, kv)
+}
+
+// Warn logs a warning message in yellow.
+// ctx is the context for extracting context values.
+// message is the log message to display.
+// kv is an optional list of key/value pairs, e.g. Warn(ctx, "msg", "key", "value").
+func (s *Service) Warn(ctx context.Context, message string, kv ...any) {
+	s.log(ctx, LevelWarn, message, kv)
 }
 
 // Error logs an error message in red.
 // ctx is the context for extracting context values.
 // message is the log message to display.
-func (s *Service) Error(ctx context.Context, message string) {
-	s.LogWithColor(ctx, colorRed, "ERROR", message)
+// kv is an optional list of key/value pairs. As a convenience, passing a
+// single error value (Error(ctx, "msg", err)) records it under the "error" key.
+func (s *Service) Error(ctx context.Context, message string, kv ...any) {
+	if len(kv) == 1 {
+		if err, ok := kv[0].(error); ok {
+			kv = []any{"error", err.Error()}
+		}
+	}
+	s.log(ctx, LevelError, message, kv)
+}
+
+// Fatal logs a message in bold red, then terminates the process via os.Exit(1).
+// ctx is the context for extracting context values.
+// message is the log message to display.
+// kv is an optional list of key/value pairs, e.g. Fatal(ctx, "msg", "key", "value").
+func (s *Service) Fatal(ctx context.Context, message string, kv ...any) {
+	s.log(ctx, LevelFatal, message, kv)
+	os.Exit(1)
+}
+
+// SetLevel sets the minimum level that will be logged. Safe to call
+// concurrently with logging calls.
+func (s *Service) SetLevel(level Level) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.level = level
+}
+
+// V reports whether level would currently be logged. Safe to call
+// concurrently with SetLevel.
+func (s *Service) V(level Level) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return level >= s.level
+}
+
+// log builds the context and caller attributes and renders the line in the
+// configured format, short-circuiting if level is below the configured threshold.
+func (s *Service) log(ctx context.Context, level Level, message string, kv []any) {
+	var (
+		contextAttrs []attr
+		kvAttrs      []attr
+		frames       []stackFrame
+		meta         = levelMeta[level]
+	)
+
+	if !s.V(level) {
+		return
+	}
+
+	contextAttrs = s.extractContextInfo(ctx)
+	kvAttrs = buildAttrs(kv)
+	if s.addStacktrace && level >= s.stacktraceLevel {
+		frames = captureStacktrace(s.stacktraceDepth)
+	}
+
+	if s.format == FormatJSON {
+		s.writeJSON(meta.Name, message, contextAttrs, kvAttrs, frames)
+		return
+	}
+	s.writeText(meta.Color, meta.Name, message, contextAttrs, kvAttrs, frames)
 }
 
-// LogWithColor formats and logs messages with color and context information.
+// LogWithColor formats and logs a text-mode message with color and context
+// information. Kept for callers that built on the original single-line API;
+// it always renders in text form regardless of the configured Format. If
+// level is a recognized level name (e.g. "DEBUG"), it is gated by the
+// configured threshold like the typed methods are.
 // ctx is the context for extracting context values.
 // color is the ANSI color code for the log level.
-// level is the log level name (INFO, SUCCESS, ERROR).
+// level is the log level name (INFO, SUCCESS, ERROR, ...).
 // message is the log message to display.
 func (s *Service) LogWithColor(ctx context.Context, color, level, message string) {
+	if lvl, ok := levelFromName(level); ok && !s.V(lvl) {
+		return
+	}
+	s.writeText(color, level, message, s.extractContextInfo(ctx), nil, nil)
+}
+
+// writeText renders a single ANSI-colored log line, followed by an
+// indented stack trace block if frames is non-empty.
+func (s *Service) writeText(color, level, message string, contextAttrs, kvAttrs []attr, frames []stackFrame) {
 	var (
-		contextInfo  string
-		contextPart  string
-		formattedMsg string
+		kvPart      string
+		contextPart string
+		stackPart   string
+		f           stackFrame
 	)
 
-	// extract context information
-	contextInfo = s.extractContextInfo(ctx)
+	kvPart = ""
+	if len(kvAttrs) > 0 {
+		kvPart = " " + formatAttrsSpace(kvAttrs)
+	}
+
 	contextPart = ""
-	if contextInfo != "" {
-		contextPart = fmt.Sprintf(" {%s}", contextInfo)
+	if len(contextAttrs) > 0 {
+		contextPart = fmt.Sprintf(" {%s}", formatAttrsComma(contextAttrs))
+	}
+
+	stackPart = ""
+	for _, f = range frames {
+		stackPart += fmt.Sprintf("\n\t%s\n\t\t%s:%d", f.Function, f.File, f.Line)
 	}
 
-	formattedMsg = fmt.Sprintf("%s[%s]%s %s%s", color, level, colorReset, message, contextPart)
-	log.Print(formattedMsg)
+	s.writeLine(fmt.Sprintf("%s[%s]%s %s%s%s%s", color, level, colorReset, message, kvPart, contextPart, stackPart))
 }
 
-// extractContextInfo extracts context values using the configured keys.
-// ctx is the context to extract values from.
-// Returns a formatted string with all extracted context values.
-func (s *Service) extractContextInfo(ctx context.Context) string {
+// writeJSON renders a single JSON object, with time/level/msg plus context
+// and caller attributes promoted to top-level fields, and a "stacktrace"
+// array when frames is non-empty. Colors are not emitted in JSON mode.
+func (s *Service) writeJSON(level, message string, contextAttrs, kvAttrs []attr, frames []stackFrame) {
+	var (
+		line []byte
+		err  error
+		a    attr
+		obj  map[string]any
+	)
+
+	obj = map[string]any{
+		"time":  time.Now().Format(time.RFC3339),
+		"level": level,
+		"msg":   message,
+	}
+	for _, a = range contextAttrs {
+		obj[a.Key] = a.Value
+	}
+	for _, a = range kvAttrs {
+		obj[a.Key] = a.Value
+	}
+	if len(frames) > 0 {
+		obj["stacktrace"] = frames
+	}
+
+	line, err = json.Marshal(obj)
+	if err != nil {
+		s.writeLine(fmt.Sprintf("[ft_logging] failed to marshal JSON log line: %v", err))
+		return
+	}
+
+	s.writeLine(string(line))
+}
+
+// buildAttrs pairs up kv into attrs, using slog's "!BADKEY" placeholder. An
+// odd trailing value is recorded under the "!BADKEY" key; a non-string key
+// and the value after it are each recorded under "!BADKEY" (unlike slog,
+// which re-pairs from the value after a non-string key).
+func buildAttrs(kv []any) []attr {
+	var (
+		attrs []attr
+		i     int
+		key   string
+		ok    bool
+	)
+
+	if len(kv) == 0 {
+		return nil
+	}
+
+	attrs = make([]attr, 0, (len(kv)+1)/2)
+	for i = 0; i < len(kv); i += 2 {
+		if i+1 >= len(kv) {
+			attrs = append(attrs, attr{Key: "!BADKEY", Value: kv[i]})
+			break
+		}
+		key, ok = kv[i].(string)
+		if !ok {
+			attrs = append(attrs, attr{Key: "!BADKEY", Value: kv[i]})
+			attrs = append(attrs, attr{Key: "!BADKEY", Value: kv[i+1]})
+			continue
+		}
+		attrs = append(attrs, attr{Key: key, Value: kv[i+1]})
+	}
+	return attrs
+}
+
+// formatAttrsComma renders attrs as "key=value, key2=value2".
+func formatAttrsComma(attrs []attr) string {
 	var (
-		parts  []string
-		key    string
-		value  any
 		result string
 		i      int
-		part   string
+		a      attr
 	)
 
-	if ctx == nil || len(s.contextKeys) == 0 {
-		return ""
+	result = ""
+	for i, a = range attrs {
+		if i > 0 {
+			result += ", "
+		}
+		result += fmt.Sprintf("%s=%v", a.Key, a.Value)
 	}
+	return result
+}
 
-	parts = []string{}
+// formatAttrsSpace renders attrs as "key=value key2=value2".
+func formatAttrsSpace(attrs []attr) string {
+	var (
+		result string
+		i      int
+		a      attr
+	)
+
+	result = ""
+	for i, a = range attrs {
+		if i > 0 {
+			result += " "
+		}
+		result += fmt.Sprintf("%s=%v", a.Key, a.Value)
+	}
+	return result
+}
+
+// extractContextInfo extracts context values using the configured string
+// keys and registered ContextAttrFuncs, merging both into a single attr
+// list deduplicated by key name (a ContextAttrFunc value overrides a
+// same-named legacy contextKeys value).
+// ctx is the context to extract values from.
+func (s *Service) extractContextInfo(ctx context.Context) []attr {
+	var (
+		attrs []attr
+		key   string
+		value any
+		fn    ContextAttrFunc
+	)
+
+	if ctx == nil {
+		return nil
+	}
 
 	// loop through configured context keys
 	for _, key = range s.contextKeys {
 		value = ctx.Value(key)
 		if value != nil {
-			parts = append(parts, fmt.Sprintf("%s=%v", key, value))
+			attrs = append(attrs, attr{Key: key, Value: value})
 		}
 	}
 
-	if len(parts) == 0 {
-		return ""
+	// loop through registered context attr funcs
+	for _, fn = range s.contextAttrFuncs {
+		attrs = append(attrs, buildAttrs(fn(ctx))...)
 	}
 
-	result = ""
-	for i, part = range parts {
-		if i > 0 {
-			result += ", "
+	if len(attrs) == 0 {
+		return nil
+	}
+	return dedupeAttrs(attrs)
+}
+
+// dedupeAttrs collapses attrs to one entry per key name, keeping each
+// key's original position but its last value.
+func dedupeAttrs(attrs []attr) []attr {
+	var (
+		result []attr
+		seen   map[string]int
+		idx    int
+		ok     bool
+		a      attr
+	)
+
+	result = make([]attr, 0, len(attrs))
+	seen = make(map[string]int, len(attrs))
+	for _, a = range attrs {
+		idx, ok = seen[a.Key]
+		if ok {
+			result[idx] = a
+			continue
 		}
-		result += part
+		seen[a.Key] = len(result)
+		result = append(result, a)
 	}
 	return result
 }